@@ -0,0 +1,68 @@
+package model
+
+// BleveSettings holds the configuration consumed by the bleveengine
+// package. Only the fields that package actually reads are defined here.
+type BleveSettings struct {
+	IndexDir            *string
+	EnableIndexing      *bool
+	EnableSearching     *bool
+	EnableAutocomplete  *bool
+	EnableHighlighting  *bool
+	FuzzyDenominator    *int
+	BatchSize           *int
+	EnableDataRetention *bool
+}
+
+func (s *BleveSettings) SetDefaults() {
+	if s.IndexDir == nil {
+		s.IndexDir = NewString("")
+	}
+
+	if s.EnableIndexing == nil {
+		s.EnableIndexing = NewBool(false)
+	}
+
+	if s.EnableSearching == nil {
+		s.EnableSearching = NewBool(false)
+	}
+
+	if s.EnableAutocomplete == nil {
+		s.EnableAutocomplete = NewBool(false)
+	}
+
+	if s.EnableHighlighting == nil {
+		s.EnableHighlighting = NewBool(false)
+	}
+
+	if s.FuzzyDenominator == nil {
+		s.FuzzyDenominator = NewInt(4)
+	}
+
+	if s.BatchSize == nil {
+		s.BatchSize = NewInt(200)
+	}
+
+	if s.EnableDataRetention == nil {
+		s.EnableDataRetention = NewBool(false)
+	}
+}
+
+type Config struct {
+	BleveSettings BleveSettings
+}
+
+func (c *Config) SetDefaults() {
+	c.BleveSettings.SetDefaults()
+}
+
+func NewString(s string) *string {
+	return &s
+}
+
+func NewBool(b bool) *bool {
+	return &b
+}
+
+func NewInt(n int) *int {
+	return &n
+}
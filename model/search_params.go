@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+const searchDateLayout = "2006-01-02"
+
+func dayBoundsMillis(date string) (int64, int64) {
+	t, err := time.Parse(searchDateLayout, date)
+	if err != nil {
+		return 0, 0
+	}
+	start := t.UnixNano() / int64(time.Millisecond)
+	end := t.AddDate(0, 0, 1).UnixNano() / int64(time.Millisecond)
+	return start, end
+}
+
+// SearchParams holds one search clause, as parsed out of a raw search
+// string by the app layer, and consumed by the search engines.
+type SearchParams struct {
+	Terms              string
+	OrTerms            bool
+	IsHashtag          bool
+	InChannels         []string
+	ExcludedChannels   []string
+	FromUsers          []string
+	ExcludedUsers      []string
+	OnDate             string
+	AfterDate          string
+	BeforeDate         string
+	ExcludedDate       string
+	ExcludedAfterDate  string
+	ExcludedBeforeDate string
+	Fuzzy              bool
+	QueryString        string
+}
+
+func (p *SearchParams) GetOnDateMillis() (int64, int64) {
+	return dayBoundsMillis(p.OnDate)
+}
+
+func (p *SearchParams) GetAfterDateMillis() int64 {
+	start, _ := dayBoundsMillis(p.AfterDate)
+	return start
+}
+
+func (p *SearchParams) GetBeforeDateMillis() int64 {
+	_, end := dayBoundsMillis(p.BeforeDate)
+	return end
+}
+
+func (p *SearchParams) GetExcludedDateMillis() (int64, int64) {
+	return dayBoundsMillis(p.ExcludedDate)
+}
+
+func (p *SearchParams) GetExcludedAfterDateMillis() int64 {
+	start, _ := dayBoundsMillis(p.ExcludedAfterDate)
+	return start
+}
+
+func (p *SearchParams) GetExcludedBeforeDateMillis() int64 {
+	_, end := dayBoundsMillis(p.ExcludedBeforeDate)
+	return end
+}
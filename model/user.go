@@ -0,0 +1,13 @@
+package model
+
+type User struct {
+	Id string
+}
+
+// UserForIndexing carries the denormalized team/channel membership a
+// search engine needs to index a user, alongside the user itself.
+type UserForIndexing struct {
+	User
+	TeamsIds    []string
+	ChannelsIds []string
+}
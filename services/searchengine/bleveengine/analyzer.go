@@ -0,0 +1,92 @@
+package bleveengine
+
+import (
+	"unicode"
+
+	"github.com/blugelabs/bleve"
+	"github.com/blugelabs/bleve/analysis"
+	"github.com/blugelabs/bleve/analysis/analyzer/custom"
+	"github.com/blugelabs/bleve/analysis/token/lowercase"
+	"github.com/blugelabs/bleve/analysis/token/unicodenorm"
+	unicodetokenizer "github.com/blugelabs/bleve/analysis/tokenizer/unicode"
+	"github.com/blugelabs/bleve/mapping"
+	"github.com/blugelabs/bleve/registry"
+)
+
+const mattermostAnalyzerName = "mattermostPost"
+
+const camelCaseFilterName = "mattermostCamelCase"
+
+const nfcFilterName = "mattermostNFC"
+
+func init() {
+	registry.RegisterTokenFilter(camelCaseFilterName, camelCaseFilterConstructor)
+}
+
+func registerMattermostAnalyzer(indexMapping *mapping.IndexMappingImpl) (*mapping.FieldMapping, error) {
+	if err := indexMapping.AddCustomTokenFilter(nfcFilterName, map[string]interface{}{
+		"type": unicodenorm.Name,
+		"form": unicodenorm.NFC,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := indexMapping.AddCustomAnalyzer(mattermostAnalyzerName, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicodetokenizer.Name,
+		"token_filters": []string{nfcFilterName, camelCaseFilterName, lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+
+	fieldMapping := bleve.NewTextFieldMapping()
+	fieldMapping.Analyzer = mattermostAnalyzerName
+	return fieldMapping, nil
+}
+
+func camelCaseFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	return camelCaseFilter{}, nil
+}
+
+type camelCaseFilter struct{}
+
+func (f camelCaseFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	output := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		parts := splitCamelCase(string(token.Term))
+		if len(parts) <= 1 {
+			output = append(output, token)
+			continue
+		}
+
+		offset := 0
+		for i, part := range parts {
+			partLen := len(part)
+			output = append(output, &analysis.Token{
+				Term:     []byte(part),
+				Start:    token.Start + offset,
+				End:      token.Start + offset + partLen,
+				Position: token.Position + i,
+				Type:     token.Type,
+			})
+			offset += partLen
+		}
+	}
+	return output
+}
+
+func splitCamelCase(s string) []string {
+	var words []string
+	var current []rune
+	for i, r := range s {
+		if i > 0 && unicode.IsUpper(r) {
+			words = append(words, string(current))
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	return words
+}
@@ -0,0 +1,63 @@
+package bleveengine
+
+import (
+	"testing"
+
+	"github.com/blugelabs/bleve/analysis"
+)
+
+func TestCamelCaseFilterFilter(t *testing.T) {
+	f := camelCaseFilter{}
+
+	input := analysis.TokenStream{
+		{Term: []byte("sendMessageToChannel"), Start: 10, End: 30, Position: 2},
+	}
+
+	output := f.Filter(input)
+
+	want := []struct {
+		term     string
+		start    int
+		end      int
+		position int
+	}{
+		{"send", 10, 14, 2},
+		{"Message", 14, 21, 3},
+		{"To", 21, 23, 4},
+		{"Channel", 23, 30, 5},
+	}
+
+	if len(output) != len(want) {
+		t.Fatalf("expected %d tokens, got %d", len(want), len(output))
+	}
+
+	for i, w := range want {
+		got := output[i]
+		if string(got.Term) != w.term {
+			t.Fatalf("token %d: expected term %q, got %q", i, w.term, string(got.Term))
+		}
+		if got.Start != w.start || got.End != w.end {
+			t.Fatalf("token %d (%q): expected Start=%d End=%d, got Start=%d End=%d", i, w.term, w.start, w.end, got.Start, got.End)
+		}
+		if got.Position != w.position {
+			t.Fatalf("token %d (%q): expected Position=%d, got Position=%d", i, w.term, w.position, got.Position)
+		}
+	}
+}
+
+func TestCamelCaseFilterFilterNoSplit(t *testing.T) {
+	f := camelCaseFilter{}
+
+	input := analysis.TokenStream{
+		{Term: []byte("message"), Start: 0, End: 7, Position: 1},
+	}
+
+	output := f.Filter(input)
+
+	if len(output) != 1 {
+		t.Fatalf("expected 1 token, got %d", len(output))
+	}
+	if output[0] != input[0] {
+		t.Fatalf("expected unsplit token to pass through unchanged, got %+v", output[0])
+	}
+}
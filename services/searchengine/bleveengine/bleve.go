@@ -1,11 +1,16 @@
 package bleveengine
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/v5/jobs"
 	"github.com/mattermost/mattermost-server/v5/mlog"
@@ -13,33 +18,29 @@ import (
 
 	"github.com/blugelabs/bleve"
 	"github.com/blugelabs/bleve/analysis/analyzer/keyword"
-	"github.com/blugelabs/bleve/analysis/analyzer/standard"
 	"github.com/blugelabs/bleve/mapping"
 	"github.com/blugelabs/bleve/search/query"
 )
 
 type BleveEngine struct {
-	postIndex    bleve.Index
-	userIndex    bleve.Index
-	channelIndex bleve.Index
-	cfg          *model.Config
-	jobServer    *jobs.JobServer
-	indexSync    bool
+	postIndex             bleve.Index
+	userIndex             bleve.Index
+	channelIndex          bleve.Index
+	cfg                   *model.Config
+	jobServer             *jobs.JobServer
+	indexSync             bool
+	deletedDocumentsCount int64
 }
 
 var emailRegex = regexp.MustCompile(`^[^\s"]+@[^\s"]+$`)
 
 var keywordMapping *mapping.FieldMapping
-var standardMapping *mapping.FieldMapping
 var dateMapping *mapping.FieldMapping
 
 func init() {
 	keywordMapping = bleve.NewTextFieldMapping()
 	keywordMapping.Analyzer = keyword.Name
 
-	standardMapping = bleve.NewTextFieldMapping()
-	standardMapping.Analyzer = standard.Name
-
 	dateMapping = bleve.NewNumericFieldMapping()
 }
 
@@ -55,51 +56,179 @@ func getChannelIndexMapping() *mapping.IndexMappingImpl {
 	return indexMapping
 }
 
-func getPostIndexMapping() *mapping.IndexMappingImpl {
+func getPostIndexMapping() (*mapping.IndexMappingImpl, error) {
+	indexMapping := bleve.NewIndexMapping()
+
+	mattermostMapping, err := registerMattermostAnalyzer(indexMapping)
+	if err != nil {
+		return nil, err
+	}
+
 	postMapping := bleve.NewDocumentMapping()
 	postMapping.AddFieldMappingsAt("Id", keywordMapping)
 	postMapping.AddFieldMappingsAt("TeamId", keywordMapping)
 	postMapping.AddFieldMappingsAt("ChannelId", keywordMapping)
 	postMapping.AddFieldMappingsAt("UserId", keywordMapping)
 	postMapping.AddFieldMappingsAt("CreateAt", dateMapping)
-	postMapping.AddFieldMappingsAt("Message", standardMapping)
+	postMapping.AddFieldMappingsAt("Message", mattermostMapping)
 	postMapping.AddFieldMappingsAt("Type", keywordMapping)
-	postMapping.AddFieldMappingsAt("Hashtags", standardMapping)
-	postMapping.AddFieldMappingsAt("Attachments", standardMapping)
+	postMapping.AddFieldMappingsAt("Hashtags", mattermostMapping)
+	postMapping.AddFieldMappingsAt("Attachments", mattermostMapping)
 
-	indexMapping := bleve.NewIndexMapping()
 	indexMapping.AddDocumentMapping("_default", postMapping)
 
-	return indexMapping
+	return indexMapping, nil
 }
 
-func getUserIndexMapping() *mapping.IndexMappingImpl {
+func getUserIndexMapping() (*mapping.IndexMappingImpl, error) {
+	indexMapping := bleve.NewIndexMapping()
+
+	mattermostMapping, err := registerMattermostAnalyzer(indexMapping)
+	if err != nil {
+		return nil, err
+	}
+
 	userMapping := bleve.NewDocumentMapping()
 	userMapping.AddFieldMappingsAt("Id", keywordMapping)
-	userMapping.AddFieldMappingsAt("SuggestionsWithFullname", keywordMapping)
-	userMapping.AddFieldMappingsAt("SuggestionsWithoutFullname", keywordMapping)
+	userMapping.AddFieldMappingsAt("SuggestionsWithFullname", mattermostMapping)
+	userMapping.AddFieldMappingsAt("SuggestionsWithoutFullname", mattermostMapping)
 	userMapping.AddFieldMappingsAt("TeamsIds", keywordMapping)
 	userMapping.AddFieldMappingsAt("ChannelsIds", keywordMapping)
 
-	indexMapping := bleve.NewIndexMapping()
 	indexMapping.AddDocumentMapping("_default", userMapping)
 
-	return indexMapping
+	return indexMapping, nil
 }
 
-func createOrOpenIndex(cfg *model.Config, indexName string, mapping *mapping.IndexMappingImpl) (bleve.Index, error) {
+const mattermostIndexVersion = 1
+
+const deleteIndexesPageSize = 1000
+
+func createOrOpenIndex(cfg *model.Config, indexName string, indexMapping *mapping.IndexMappingImpl) (bleve.Index, error) {
 	indexPath := filepath.Join(*cfg.BleveSettings.IndexDir, indexName+".bleve")
+	versionPath := indexPath + ".version"
+
 	if index, err := bleve.Open(indexPath); err == nil {
-		return index, nil
+		if isCurrentIndexVersion(versionPath) {
+			return index, nil
+		}
+
+		mlog.Warn("Bleve index mapping is outdated, rebuilding", mlog.String("index", indexName))
+		if err := index.Close(); err != nil {
+			return nil, err
+		}
+
+		stalePath := indexPath + ".stale"
+		os.RemoveAll(stalePath)
+		if err := os.Rename(indexPath, stalePath); err != nil {
+			return nil, err
+		}
 	}
 
-	index, err := bleve.New(indexPath, mapping)
+	index, err := bleve.New(indexPath, indexMapping)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := writeIndexVersion(versionPath); err != nil {
+		return nil, err
+	}
+
 	return index, nil
 }
 
+func isCurrentIndexVersion(versionPath string) bool {
+	data, err := ioutil.ReadFile(versionPath)
+	if err != nil {
+		return false
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return err == nil && version == mattermostIndexVersion
+}
+
+func writeIndexVersion(versionPath string) error {
+	return ioutil.WriteFile(versionPath, []byte(strconv.Itoa(mattermostIndexVersion)), 0644)
+}
+
+func (b *BleveEngine) batchSize() int {
+	size := *b.cfg.BleveSettings.BatchSize
+	if size <= 0 {
+		size = 200
+	}
+	return size
+}
+
+func (b *BleveEngine) fuzziness(term string) int {
+	runeCount := utf8.RuneCountInString(term)
+	if runeCount <= 3 {
+		return 0
+	}
+
+	denominator := *b.cfg.BleveSettings.FuzzyDenominator
+	if denominator <= 0 {
+		denominator = 4
+	}
+
+	f := runeCount / denominator
+	if f > 2 {
+		f = 2
+	}
+	return f
+}
+
+var quotedTermRegex = regexp.MustCompile(`"[^"]*"|\S+`)
+
+func (b *BleveEngine) buildMessageQuery(params *model.SearchParams) query.Query {
+	if params.QueryString != "" {
+		return bleve.NewQueryStringQuery(params.QueryString)
+	}
+
+	if params.IsHashtag {
+		hashtagQ := bleve.NewMatchQuery(params.Terms)
+		hashtagQ.SetField("Hashtags")
+		return hashtagQ
+	}
+
+	if params.Fuzzy {
+		fuzzyTermQueries := []query.Query{}
+		for _, term := range strings.Fields(params.Terms) {
+			fuzzyQ := bleve.NewFuzzyQuery(term)
+			fuzzyQ.SetFuzziness(b.fuzziness(term))
+			fuzzyQ.SetPrefix(1)
+			fuzzyQ.SetField("Message")
+			fuzzyTermQueries = append(fuzzyTermQueries, fuzzyQ)
+		}
+		return bleve.NewConjunctionQuery(fuzzyTermQueries...)
+	}
+
+	subQueries := []query.Query{}
+	for _, token := range quotedTermRegex.FindAllString(params.Terms, -1) {
+		if strings.HasPrefix(token, `"`) && strings.HasSuffix(token, `"`) && len(token) > 1 {
+			phraseQ := bleve.NewMatchPhraseQuery(strings.Trim(token, `"`))
+			phraseQ.SetField("Message")
+			subQueries = append(subQueries, phraseQ)
+			continue
+		}
+
+		matchQ := bleve.NewMatchQuery(token)
+		matchQ.SetField("Message")
+		subQueries = append(subQueries, matchQ)
+	}
+
+	if len(subQueries) == 0 {
+		matchQ := bleve.NewMatchQuery(params.Terms)
+		matchQ.SetField("Message")
+		return matchQ
+	}
+
+	if len(subQueries) == 1 {
+		return subQueries[0]
+	}
+
+	return bleve.NewConjunctionQuery(subQueries...)
+}
+
 func NewBleveEngine(cfg *model.Config, jobServer *jobs.JobServer) *BleveEngine {
 	return &BleveEngine{
 		cfg:       cfg,
@@ -113,13 +242,21 @@ func (b *BleveEngine) Start() *model.AppError {
 	}
 
 	mlog.Warn("Starting Bleve")
-	var err error
-	b.postIndex, err = createOrOpenIndex(b.cfg, "posts", getPostIndexMapping())
+
+	postIndexMapping, err := getPostIndexMapping()
+	if err != nil {
+		return model.NewAppError("Bleveengine.Start", "bleveengine.create_post_index.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	b.postIndex, err = createOrOpenIndex(b.cfg, "posts", postIndexMapping)
 	if err != nil {
 		return model.NewAppError("Bleveengine.Start", "bleveengine.create_post_index.error", nil, err.Error(), http.StatusInternalServerError)
 	}
 
-	b.userIndex, err = createOrOpenIndex(b.cfg, "users", getUserIndexMapping())
+	userIndexMapping, err := getUserIndexMapping()
+	if err != nil {
+		return model.NewAppError("Bleveengine.Start", "bleveengine.create_user_index.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	b.userIndex, err = createOrOpenIndex(b.cfg, "users", userIndexMapping)
 	if err != nil {
 		return model.NewAppError("Bleveengine.Start", "bleveengine.create_user_index.error", nil, err.Error(), http.StatusInternalServerError)
 	}
@@ -163,7 +300,7 @@ func (b *BleveEngine) RefreshIndexes() *model.AppError {
 }
 
 func (b *BleveEngine) GetVersion() int {
-	return 0
+	return mattermostIndexVersion
 }
 
 func (b *BleveEngine) GetName() string {
@@ -178,6 +315,33 @@ func (b *BleveEngine) IndexPost(post *model.Post, teamId string) *model.AppError
 	return nil
 }
 
+func (b *BleveEngine) BulkIndexPosts(posts []*model.Post, teamIds map[string]string) *model.AppError {
+	batch := b.postIndex.NewBatch()
+	batchSize := b.batchSize()
+
+	for _, post := range posts {
+		blvPost := BLVPostFromPost(post, teamIds[post.Id])
+		if err := batch.Index(blvPost.Id, blvPost); err != nil {
+			return model.NewAppError("Bleveengine.BulkIndexPosts", "bleveengine.bulk_index_posts.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		if batch.Size() >= batchSize {
+			if err := b.postIndex.Batch(batch); err != nil {
+				return model.NewAppError("Bleveengine.BulkIndexPosts", "bleveengine.bulk_index_posts.error", nil, err.Error(), http.StatusInternalServerError)
+			}
+			batch = b.postIndex.NewBatch()
+		}
+	}
+
+	if batch.Size() > 0 {
+		if err := b.postIndex.Batch(batch); err != nil {
+			return model.NewAppError("Bleveengine.BulkIndexPosts", "bleveengine.bulk_index_posts.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
 func (b *BleveEngine) SearchPosts(channels *model.ChannelList, searchParams []*model.SearchParams, page, perPage int) ([]string, model.PostSearchMatches, *model.AppError) {
 	channelQueries := []query.Query{}
 	for _, channel := range *channels {
@@ -285,9 +449,7 @@ func (b *BleveEngine) SearchPosts(channels *model.ChannelList, searchParams []*m
 			}
 		}
 
-		messageQ := bleve.NewMatchQuery(params.Terms)
-		messageQ.SetField("Message")
-		termQueries = append(termQueries, messageQ)
+		termQueries = append(termQueries, b.buildMessageQuery(params))
 	}
 
 	var allTermsQ query.Query
@@ -309,17 +471,27 @@ func (b *BleveEngine) SearchPosts(channels *model.ChannelList, searchParams []*m
 		query.AddMustNot(notFilters...)
 	}
 
-	search := bleve.NewSearchRequest(query)
+	search := bleve.NewSearchRequestOptions(query, perPage, page*perPage, false)
+	if *b.cfg.BleveSettings.EnableHighlighting {
+		search.Highlight = bleve.NewHighlightWithStyle("html")
+		search.Highlight.AddField("Message")
+	}
+
 	results, err := b.postIndex.Search(search)
 	if err != nil {
 		return nil, nil, model.NewAppError("Bleveengine.SearchPosts", "bleveengine.search_posts.error", nil, err.Error(), http.StatusInternalServerError)
 	}
 
+	mlog.Debug("Bleve query profiling", mlog.String("index", "posts"), mlog.Int64("took_ms", results.Took.Milliseconds()), mlog.Int64("total_hits", int64(results.Total)))
+
 	postIds := []string{}
 	matches := model.PostSearchMatches{}
 
 	for _, r := range results.Hits {
 		postIds = append(postIds, r.ID)
+		if fragments, ok := r.Fragments["Message"]; ok {
+			matches[r.ID] = fragments
+		}
 	}
 
 	return postIds, matches, nil
@@ -340,23 +512,65 @@ func (b *BleveEngine) IndexChannel(channel *model.Channel) *model.AppError {
 	return nil
 }
 
+func (b *BleveEngine) BulkIndexChannels(channels []*model.Channel) *model.AppError {
+	batch := b.channelIndex.NewBatch()
+	batchSize := b.batchSize()
+
+	for _, channel := range channels {
+		blvChannel := BLVChannelFromChannel(channel)
+		if err := batch.Index(blvChannel.Id, blvChannel); err != nil {
+			return model.NewAppError("Bleveengine.BulkIndexChannels", "bleveengine.bulk_index_channels.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		if batch.Size() >= batchSize {
+			if err := b.channelIndex.Batch(batch); err != nil {
+				return model.NewAppError("Bleveengine.BulkIndexChannels", "bleveengine.bulk_index_channels.error", nil, err.Error(), http.StatusInternalServerError)
+			}
+			batch = b.channelIndex.NewBatch()
+		}
+	}
+
+	if batch.Size() > 0 {
+		if err := b.channelIndex.Batch(batch); err != nil {
+			return model.NewAppError("Bleveengine.BulkIndexChannels", "bleveengine.bulk_index_channels.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+const searchChannelsLimit = 100
+
 func (b *BleveEngine) SearchChannels(teamId, term string) ([]string, *model.AppError) {
 	teamIdQ := bleve.NewTermQuery(teamId)
 	teamIdQ.SetField("TeamId")
 	queries := []query.Query{teamIdQ}
 
 	if term != "" {
-		nameSuggestQ := bleve.NewPrefixQuery(strings.ToLower(term))
-		nameSuggestQ.SetField("NameSuggest")
+		var nameSuggestQ query.Query
+		if strings.HasSuffix(term, "~") {
+			term = strings.TrimSuffix(term, "~")
+			fuzzyQ := bleve.NewFuzzyQuery(strings.ToLower(term))
+			fuzzyQ.SetFuzziness(b.fuzziness(term))
+			fuzzyQ.SetPrefix(1)
+			fuzzyQ.SetField("NameSuggest")
+			nameSuggestQ = fuzzyQ
+		} else {
+			prefixQ := bleve.NewPrefixQuery(strings.ToLower(term))
+			prefixQ.SetField("NameSuggest")
+			nameSuggestQ = prefixQ
+		}
 		queries = append(queries, nameSuggestQ)
 	}
 
-	query := bleve.NewSearchRequest(bleve.NewConjunctionQuery(queries...))
+	query := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(queries...), searchChannelsLimit, 0, false)
 	results, err := b.channelIndex.Search(query)
 	if err != nil {
 		return nil, model.NewAppError("Bleveengine.SearchChannels", "bleveengine.search_channels.error", nil, err.Error(), http.StatusInternalServerError)
 	}
 
+	mlog.Debug("Bleve query profiling", mlog.String("index", "channels"), mlog.Int64("took_ms", results.Took.Milliseconds()), mlog.Int64("total_hits", int64(results.Total)))
+
 	channelIds := []string{}
 	for _, result := range results.Hits {
 		channelIds = append(channelIds, result.ID)
@@ -380,6 +594,55 @@ func (b *BleveEngine) IndexUser(user *model.User, teamsIds, channelsIds []string
 	return nil
 }
 
+func (b *BleveEngine) BulkIndexUsers(users []*model.UserForIndexing) *model.AppError {
+	batch := b.userIndex.NewBatch()
+	batchSize := b.batchSize()
+
+	for _, user := range users {
+		blvUser := BLVUserFromUserAndTeams(&user.User, user.TeamsIds, user.ChannelsIds)
+		if err := batch.Index(blvUser.Id, blvUser); err != nil {
+			return model.NewAppError("Bleveengine.BulkIndexUsers", "bleveengine.bulk_index_users.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		if batch.Size() >= batchSize {
+			if err := b.userIndex.Batch(batch); err != nil {
+				return model.NewAppError("Bleveengine.BulkIndexUsers", "bleveengine.bulk_index_users.error", nil, err.Error(), http.StatusInternalServerError)
+			}
+			batch = b.userIndex.NewBatch()
+		}
+	}
+
+	if batch.Size() > 0 {
+		if err := b.userIndex.Batch(batch); err != nil {
+			return model.NewAppError("Bleveengine.BulkIndexUsers", "bleveengine.bulk_index_users.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+func (b *BleveEngine) userSuggestionQuery(term string, allowFullNames bool) query.Query {
+	field := "SuggestionsWithoutFullname"
+	if allowFullNames {
+		field = "SuggestionsWithFullname"
+	}
+
+	var termQ query.Query
+	if strings.HasSuffix(term, "~") {
+		term = strings.TrimSuffix(term, "~")
+		fuzzyQ := bleve.NewFuzzyQuery(strings.ToLower(term))
+		fuzzyQ.SetFuzziness(b.fuzziness(term))
+		fuzzyQ.SetPrefix(1)
+		fuzzyQ.SetField(field)
+		termQ = fuzzyQ
+	} else {
+		prefixQ := bleve.NewPrefixQuery(strings.ToLower(term))
+		prefixQ.SetField(field)
+		termQ = prefixQ
+	}
+	return termQ
+}
+
 func (b *BleveEngine) SearchUsersInChannel(teamId, channelId string, restrictedToChannels []string, term string, options *model.UserSearchOptions) ([]string, []string, *model.AppError) {
 	if restrictedToChannels != nil && len(restrictedToChannels) == 0 {
 		return []string{}, []string{}, nil
@@ -387,13 +650,7 @@ func (b *BleveEngine) SearchUsersInChannel(teamId, channelId string, restrictedT
 
 	var queries []query.Query
 	if term != "" {
-		termQ := bleve.NewPrefixQuery(strings.ToLower(term))
-		if options.AllowFullNames {
-			termQ.SetField("SuggestionsWithFullname")
-		} else {
-			termQ.SetField("SuggestionsWithoutFullname")
-		}
-		queries = append(queries, termQ)
+		queries = append(queries, b.userSuggestionQuery(term, options.AllowFullNames))
 	}
 
 	channelIdQ := bleve.NewTermQuery(channelId)
@@ -402,7 +659,7 @@ func (b *BleveEngine) SearchUsersInChannel(teamId, channelId string, restrictedT
 
 	query := bleve.NewConjunctionQuery(queries...)
 
-	uchan, err := b.userIndex.Search(bleve.NewSearchRequest(query))
+	uchan, err := b.userIndex.Search(bleve.NewSearchRequestOptions(query, options.Limit, 0, false))
 	if err != nil {
 		return nil, nil, model.NewAppError("Bleveengine.SearchUsersInChannel", "bleveengine.search_users_in_channel.uchan.error", nil, err.Error(), http.StatusInternalServerError)
 	}
@@ -410,13 +667,7 @@ func (b *BleveEngine) SearchUsersInChannel(teamId, channelId string, restrictedT
 	boolQ := bleve.NewBooleanQuery()
 
 	if term != "" {
-		termQ := bleve.NewPrefixQuery(strings.ToLower(term))
-		if options.AllowFullNames {
-			termQ.SetField("SuggestionsWithFullname")
-		} else {
-			termQ.SetField("SuggestionsWithoutFullname")
-		}
-		boolQ.AddMust(termQ)
+		boolQ.AddMust(b.userSuggestionQuery(term, options.AllowFullNames))
 	}
 
 	teamIdQ := bleve.NewTermQuery(teamId)
@@ -436,7 +687,7 @@ func (b *BleveEngine) SearchUsersInChannel(teamId, channelId string, restrictedT
 		boolQ.AddMust(restrictedChannelsQ)
 	}
 
-	nuchan, err := b.userIndex.Search(bleve.NewSearchRequest(boolQ))
+	nuchan, err := b.userIndex.Search(bleve.NewSearchRequestOptions(boolQ, options.Limit, 0, false))
 	if err != nil {
 		return nil, nil, model.NewAppError("Bleveengine.SearchUsersInChannel", "bleveengine.search_users_in_channel.nuchan.error", nil, err.Error(), http.StatusInternalServerError)
 	}
@@ -466,13 +717,7 @@ func (b *BleveEngine) SearchUsersInTeam(teamId string, restrictedToChannels []st
 		boolQ := bleve.NewBooleanQuery()
 
 		if term != "" {
-			termQ := bleve.NewPrefixQuery(strings.ToLower(term))
-			if options.AllowFullNames {
-				termQ.SetField("SuggestionsWithFullname")
-			} else {
-				termQ.SetField("SuggestionsWithoutFullname")
-			}
-			boolQ.AddMust(termQ)
+			boolQ.AddMust(b.userSuggestionQuery(term, options.AllowFullNames))
 		}
 
 		if len(restrictedToChannels) > 0 {
@@ -497,7 +742,7 @@ func (b *BleveEngine) SearchUsersInTeam(teamId string, restrictedToChannels []st
 		rootQ = boolQ
 	}
 
-	search := bleve.NewSearchRequest(rootQ)
+	search := bleve.NewSearchRequestOptions(rootQ, options.Limit, 0, false)
 
 	results, err := b.userIndex.Search(search)
 	if err != nil {
@@ -524,15 +769,103 @@ func (b *BleveEngine) TestConfig(cfg *model.Config) *model.AppError {
 	return nil
 }
 
+func (b *BleveEngine) purgeIndex(name string, index bleve.Index, indexMapping *mapping.IndexMappingImpl) (bleve.Index, *model.AppError) {
+	if index != nil {
+		if err := index.Close(); err != nil {
+			return nil, model.NewAppError("Bleveengine.PurgeIndexes", "bleveengine.purge_indexes.close.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	indexPath := filepath.Join(*b.cfg.BleveSettings.IndexDir, name+".bleve")
+	if err := os.RemoveAll(indexPath); err != nil {
+		return nil, model.NewAppError("Bleveengine.PurgeIndexes", "bleveengine.purge_indexes.remove.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	os.Remove(indexPath + ".version")
+
+	newIndex, err := createOrOpenIndex(b.cfg, name, indexMapping)
+	if err != nil {
+		return nil, model.NewAppError("Bleveengine.PurgeIndexes", "bleveengine.purge_indexes.create.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return newIndex, nil
+}
+
 func (b *BleveEngine) PurgeIndexes() *model.AppError {
-	mlog.Warn("PurgeIndexes Bleve")
+	mlog.Warn("Purging Bleve indexes")
+
+	postIndexMapping, err := getPostIndexMapping()
+	if err != nil {
+		return model.NewAppError("Bleveengine.PurgeIndexes", "bleveengine.purge_indexes.mapping.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	postIndex, appErr := b.purgeIndex("posts", b.postIndex, postIndexMapping)
+	if appErr != nil {
+		return appErr
+	}
+	b.postIndex = postIndex
+
+	userIndexMapping, err := getUserIndexMapping()
+	if err != nil {
+		return model.NewAppError("Bleveengine.PurgeIndexes", "bleveengine.purge_indexes.mapping.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	userIndex, appErr := b.purgeIndex("users", b.userIndex, userIndexMapping)
+	if appErr != nil {
+		return appErr
+	}
+	b.userIndex = userIndex
+
+	channelIndex, appErr := b.purgeIndex("channels", b.channelIndex, getChannelIndexMapping())
+	if appErr != nil {
+		return appErr
+	}
+	b.channelIndex = channelIndex
+
 	return nil
 }
 
 func (b *BleveEngine) DataRetentionDeleteIndexes(cutoff time.Time) *model.AppError {
+	if !*b.cfg.BleveSettings.EnableIndexing || !*b.cfg.BleveSettings.EnableDataRetention {
+		return nil
+	}
+
+	minCreateAt := float64(0)
+	maxCreateAt := float64(cutoff.UnixNano() / int64(time.Millisecond))
+	minInclusive, maxInclusive := true, true
+	cutoffQ := bleve.NewNumericRangeInclusiveQuery(&minCreateAt, &maxCreateAt, &minInclusive, &maxInclusive)
+	cutoffQ.SetField("CreateAt")
+
+	deleted := 0
+	for {
+		search := bleve.NewSearchRequestOptions(cutoffQ, deleteIndexesPageSize, 0, false)
+		results, err := b.postIndex.Search(search)
+		if err != nil {
+			return model.NewAppError("Bleveengine.DataRetentionDeleteIndexes", "bleveengine.data_retention_delete_indexes.search.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		if len(results.Hits) == 0 {
+			break
+		}
+
+		batch := b.postIndex.NewBatch()
+		for _, hit := range results.Hits {
+			batch.Delete(hit.ID)
+		}
+
+		if err := b.postIndex.Batch(batch); err != nil {
+			return model.NewAppError("Bleveengine.DataRetentionDeleteIndexes", "bleveengine.data_retention_delete_indexes.delete.error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		deleted += len(results.Hits)
+	}
+
+	atomic.AddInt64(&b.deletedDocumentsCount, int64(deleted))
+	mlog.Debug("Bleve data retention purge complete", mlog.Int("posts_deleted", deleted), mlog.Int64("cutoff_ms", int64(maxCreateAt)))
+
 	return nil
 }
 
+func (b *BleveEngine) DeletedDocumentsCount() int64 {
+	return atomic.LoadInt64(&b.deletedDocumentsCount)
+}
+
 func (b *BleveEngine) IsAutocompletionEnabled() bool {
 	return *b.cfg.BleveSettings.EnableAutocomplete
 }
@@ -0,0 +1,72 @@
+package bleveengine
+
+import (
+	"testing"
+
+	"github.com/blugelabs/bleve/search/query"
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+func TestFuzziness(t *testing.T) {
+	b := &BleveEngine{cfg: &model.Config{BleveSettings: model.BleveSettings{FuzzyDenominator: model.NewInt(4)}}}
+
+	t.Run("short ASCII term is exact", func(t *testing.T) {
+		if f := b.fuzziness("abc"); f != 0 {
+			t.Fatalf("expected 0, got %d", f)
+		}
+	})
+
+	t.Run("short multi-byte term is exact", func(t *testing.T) {
+		// 3 runes, 9 bytes: a byte-length check would wrongly treat this as long.
+		if f := b.fuzziness("日本語"); f != 0 {
+			t.Fatalf("expected 0, got %d", f)
+		}
+	})
+
+	t.Run("long multi-byte term scales by rune count", func(t *testing.T) {
+		// 6 runes, 18 bytes: rune-count/4 = 1, byte-length/4 would give 4 (clamped to 2).
+		if f := b.fuzziness("日本語日本語"); f != 1 {
+			t.Fatalf("expected 1, got %d", f)
+		}
+	})
+}
+
+func TestBuildMessageQuery(t *testing.T) {
+	b := &BleveEngine{}
+
+	t.Run("quoted phrase", func(t *testing.T) {
+		q := b.buildMessageQuery(&model.SearchParams{Terms: `"hello world"`})
+		if _, ok := q.(*query.MatchPhraseQuery); !ok {
+			t.Fatalf("expected *query.MatchPhraseQuery, got %T", q)
+		}
+	})
+
+	t.Run("hashtag", func(t *testing.T) {
+		q := b.buildMessageQuery(&model.SearchParams{Terms: "#foo", IsHashtag: true})
+		matchQ, ok := q.(*query.MatchQuery)
+		if !ok {
+			t.Fatalf("expected *query.MatchQuery, got %T", q)
+		}
+		if matchQ.FieldVal != "Hashtags" {
+			t.Fatalf("expected Hashtags field, got %q", matchQ.FieldVal)
+		}
+	})
+
+	t.Run("query string", func(t *testing.T) {
+		q := b.buildMessageQuery(&model.SearchParams{QueryString: "Message:foo"})
+		if _, ok := q.(*query.QueryStringQuery); !ok {
+			t.Fatalf("expected *query.QueryStringQuery, got %T", q)
+		}
+	})
+
+	t.Run("mixed bare word and quoted phrase", func(t *testing.T) {
+		q := b.buildMessageQuery(&model.SearchParams{Terms: `foo "bar baz"`})
+		conjQ, ok := q.(*query.ConjunctionQuery)
+		if !ok {
+			t.Fatalf("expected *query.ConjunctionQuery, got %T", q)
+		}
+		if len(conjQ.Conjuncts) != 2 {
+			t.Fatalf("expected 2 sub-queries, got %d", len(conjQ.Conjuncts))
+		}
+	})
+}